@@ -0,0 +1,197 @@
+// Copyright 2018 Vixeka Software Solutions, Inc.
+// This file is part of the Theos library.
+//
+// The Theos library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Theos library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Theos library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package gasprice implements a dynamic gas price oracle for transactions
+// that fall outside the fixed-price path.
+package gasprice
+
+import (
+	"math/big"
+	"sort"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/event"
+)
+
+// Config are the configuration parameters of the gas price oracle.
+type Config struct {
+	Blocks      int      // Number of recent blocks to sample transaction gas prices from.
+	Percentile  int      // Percentile (0-100) of the sampled prices to suggest.
+	MinGasPrice *big.Int // Lower bound the suggested price is clamped to.
+	MaxGasPrice *big.Int // Upper bound the suggested price is clamped to.
+}
+
+// DefaultConfig contains the default settings for the gas price oracle.
+var DefaultConfig = Config{
+	Blocks:      20,
+	Percentile:  60,
+	MinGasPrice: big.NewInt(1000000000),   // 1 gwei
+	MaxGasPrice: big.NewInt(500000000000), // 500 gwei
+}
+
+// Backend supplies the Oracle with the recent chain data it needs to sample
+// gas prices. It is deliberately narrow, and kept separate from core's own
+// database accessors, so this package does not import core (which in turn
+// needs to import this package to drive GasPriceManager).
+type Backend interface {
+	// RecentBlocks returns up to n blocks ending at the current head, ordered
+	// newest first.
+	RecentBlocks(n int) []*types.Block
+}
+
+// Oracle recommends a gas price for transactions that are not eligible for
+// the fixed price, based on the lowest gas prices paid in recent blocks.
+type Oracle struct {
+	backend Backend
+	config  Config
+
+	cacheLock sync.Mutex
+	lastPrice *big.Int
+
+	updateFeed event.Feed
+	scope      event.SubscriptionScope
+}
+
+// NewOracle creates a gas price oracle backed by backend, using config (or
+// DefaultConfig's values for Blocks, MinGasPrice and MaxGasPrice when left
+// unset). Percentile is clamped to 0-100; a zero Percentile stays zero.
+func NewOracle(backend Backend, config Config) *Oracle {
+	if config.Blocks <= 0 {
+		config.Blocks = DefaultConfig.Blocks
+	}
+	if config.Percentile < 0 {
+		config.Percentile = 0
+	}
+	if config.Percentile > 100 {
+		config.Percentile = 100
+	}
+	if config.MinGasPrice == nil {
+		config.MinGasPrice = DefaultConfig.MinGasPrice
+	}
+	if config.MaxGasPrice == nil {
+		config.MaxGasPrice = DefaultConfig.MaxGasPrice
+	}
+
+	return &Oracle{
+		backend:   backend,
+		config:    config,
+		lastPrice: new(big.Int).Set(config.MinGasPrice),
+	}
+}
+
+// SuggestedPrice returns the oracle's most recently cached suggestion. The
+// cache is refreshed by Update, which GasPriceManager.ApplyBlock calls once
+// per imported block, so repeated calls (e.g. one per eth_gasPrice request)
+// do not each re-scan the chain.
+func (gpo *Oracle) SuggestedPrice() *big.Int {
+	gpo.cacheLock.Lock()
+	defer gpo.cacheLock.Unlock()
+	return new(big.Int).Set(gpo.lastPrice)
+}
+
+// Update resamples the configured window of recent blocks and refreshes the
+// cached suggestion returned by SuggestedPrice. If the suggestion changed,
+// it is published to subscribers registered with SubscribeSuggestedPriceUpdate
+// after the cache lock is released, since event.Feed.Send blocks until every
+// subscriber has received the value and a subscriber calling back into the
+// oracle (e.g. the txpool's reprice handler) would otherwise deadlock.
+func (gpo *Oracle) Update() {
+	price := gpo.samplePrice()
+
+	gpo.cacheLock.Lock()
+	changed := price.Cmp(gpo.lastPrice) != 0
+	if changed {
+		gpo.lastPrice = price
+	}
+	gpo.cacheLock.Unlock()
+
+	if changed {
+		gpo.updateFeed.Send(new(big.Int).Set(price))
+	}
+}
+
+// samplePrice collects the lowest gas price paid in each of the last
+// config.Blocks blocks and returns the configured percentile of that sample,
+// clamped between MinGasPrice and MaxGasPrice.
+func (gpo *Oracle) samplePrice() *big.Int {
+	blocks := gpo.backend.RecentBlocks(gpo.config.Blocks)
+
+	var prices []*big.Int
+	for _, block := range blocks {
+		if lowest := lowestGasPrice(block); lowest != nil {
+			prices = append(prices, lowest)
+		}
+	}
+	if len(prices) == 0 {
+		return clamp(new(big.Int).Set(gpo.config.MinGasPrice), gpo.config)
+	}
+
+	sort.Slice(prices, func(i, j int) bool { return prices[i].Cmp(prices[j]) < 0 })
+	index := (len(prices) - 1) * gpo.config.Percentile / 100
+	return clamp(new(big.Int).Set(prices[index]), gpo.config)
+}
+
+// lowestGasPrice returns the lowest gas price paid by any transaction in
+// block, or nil if the block has none.
+func lowestGasPrice(block *types.Block) *big.Int {
+	var lowest *big.Int
+	for _, tx := range block.Transactions() {
+		if lowest == nil || tx.GasPrice().Cmp(lowest) < 0 {
+			lowest = tx.GasPrice()
+		}
+	}
+	return lowest
+}
+
+// clamp bounds price between config.MinGasPrice and config.MaxGasPrice.
+func clamp(price *big.Int, config Config) *big.Int {
+	if config.MinGasPrice != nil && price.Cmp(config.MinGasPrice) < 0 {
+		return new(big.Int).Set(config.MinGasPrice)
+	}
+	if config.MaxGasPrice != nil && price.Cmp(config.MaxGasPrice) > 0 {
+		return new(big.Int).Set(config.MaxGasPrice)
+	}
+	return price
+}
+
+// SubscribeSuggestedPriceUpdate registers ch to receive the oracle's new
+// suggestion whenever it changes, so subscribers such as the txpool can
+// reprice queued transactions when the suggested price jumps.
+func (gpo *Oracle) SubscribeSuggestedPriceUpdate(ch chan<- *big.Int) event.Subscription {
+	return gpo.scope.Track(gpo.updateFeed.Subscribe(ch))
+}
+
+// Stop closes every subscription registered with the oracle.
+func (gpo *Oracle) Stop() {
+	gpo.scope.Close()
+}
+
+// PublicGasPriceAPI exposes the oracle's suggestion over RPC as the standard
+// eth_gasPrice method.
+type PublicGasPriceAPI struct {
+	oracle *Oracle
+}
+
+// NewPublicGasPriceAPI creates the RPC service backed by oracle.
+func NewPublicGasPriceAPI(oracle *Oracle) *PublicGasPriceAPI {
+	return &PublicGasPriceAPI{oracle: oracle}
+}
+
+// GasPrice returns the currently suggested gas price, served as eth_gasPrice.
+func (api *PublicGasPriceAPI) GasPrice() *big.Int {
+	return api.oracle.SuggestedPrice()
+}
@@ -0,0 +1,264 @@
+// Copyright 2018 Vixeka Software Solutions, Inc.
+// This file is part of the Theos library.
+//
+// The Theos library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Theos library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Theos library. If not, see <http://www.gnu.org/licenses/>.
+
+package gasprice
+
+import (
+	"crypto/ecdsa"
+	"errors"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// Source is how GasPriceManager learns the values it needs to price
+// transactions. LocalSource computes them itself (the historical behavior);
+// KafkaSource and FallbackSource let a node instead follow a trusted
+// sequencer/coordinator.
+type Source interface {
+	SuggestedGasPrice() *big.Int
+	MinGasPrice() *big.Int
+	FixedPriceGasLimit() uint64
+}
+
+// LocalSource reads the three values from closures supplied by
+// GasPriceManager, so this package does not need to depend on core (which
+// depends on this package) to wrap the manager's own oracle and config.
+type LocalSource struct {
+	SuggestedGasPriceFn  func() *big.Int
+	MinGasPriceFn        func() *big.Int
+	FixedPriceGasLimitFn func() uint64
+}
+
+func (s *LocalSource) SuggestedGasPrice() *big.Int { return s.SuggestedGasPriceFn() }
+func (s *LocalSource) MinGasPrice() *big.Int       { return s.MinGasPriceFn() }
+func (s *LocalSource) FixedPriceGasLimit() uint64  { return s.FixedPriceGasLimitFn() }
+
+// FeedMessage is the payload published by a trusted sequencer/coordinator
+// for nodes running in follower mode, over Kafka or a signed HTTP stream.
+type FeedMessage struct {
+	Timestamp          uint64
+	SuggestedGasPrice  *big.Int
+	MinGasPrice        *big.Int
+	FixedPriceGasLimit uint64
+	Signature          []byte // 65-byte recoverable signature over the fields above
+}
+
+// feedMessagePayload is the subset of FeedMessage that gets signed.
+type feedMessagePayload struct {
+	Timestamp          uint64
+	SuggestedGasPrice  *big.Int
+	MinGasPrice        *big.Int
+	FixedPriceGasLimit uint64
+}
+
+func (m *FeedMessage) digest() ([]byte, error) {
+	raw, err := rlp.EncodeToBytes(feedMessagePayload{m.Timestamp, m.SuggestedGasPrice, m.MinGasPrice, m.FixedPriceGasLimit})
+	if err != nil {
+		return nil, err
+	}
+	return crypto.Keccak256(raw), nil
+}
+
+// verify checks that m was signed by the holder of pubkey, so a compromised
+// feed cannot be impersonated.
+func (m *FeedMessage) verify(pubkey *ecdsa.PublicKey) error {
+	if len(m.Signature) != 65 {
+		return errors.New("gasprice: invalid feed message signature length")
+	}
+	digest, err := m.digest()
+	if err != nil {
+		return err
+	}
+	recovered, err := crypto.SigToPub(digest, m.Signature)
+	if err != nil {
+		return err
+	}
+	if crypto.PubkeyToAddress(*recovered) != crypto.PubkeyToAddress(*pubkey) {
+		return errors.New("gasprice: feed message not signed by the configured publisher")
+	}
+	return nil
+}
+
+// KafkaConsumer is the minimal surface KafkaSource needs from a consumer
+// group client. Keeping it this narrow lets any Kafka client library back
+// it without this package depending on one directly.
+type KafkaConsumer interface {
+	// Messages delivers the raw, RLP-encoded FeedMessage payloads consumed
+	// from the configured brokers/topic/consumer group. It is closed when
+	// the consumer group shuts down.
+	Messages() <-chan []byte
+	Close() error
+}
+
+// KafkaSourceConfig configures a KafkaSource.
+type KafkaSourceConfig struct {
+	PublisherKey     *ecdsa.PublicKey // Public key the feed's messages must be signed with.
+	StalenessTimeout time.Duration    // How long without a message before falling back to LocalSource.
+	MinGasPrice      *big.Int         // Lower bound clamped onto every value read from the feed.
+	MaxGasPrice      *big.Int         // Upper bound clamped onto every value read from the feed.
+}
+
+// defaultStalenessTimeout is used when StalenessTimeout is left unset
+// (zero), so a zero-value KafkaSourceConfig doesn't make stale() trivially
+// true and silently disable follower mode.
+const defaultStalenessTimeout = 30 * time.Second
+
+// KafkaSource follows a Kafka topic (or any transport satisfying
+// KafkaConsumer) publishing signed FeedMessages from a trusted sequencer or
+// coordinator, instead of computing gas prices locally.
+type KafkaSource struct {
+	consumer KafkaConsumer
+	config   KafkaSourceConfig
+
+	mu       sync.RWMutex
+	last     FeedMessage
+	lastSeen time.Time
+
+	quit chan struct{}
+}
+
+// NewKafkaSource creates a KafkaSource and starts consuming messages from
+// consumer in the background.
+func NewKafkaSource(consumer KafkaConsumer, config KafkaSourceConfig) *KafkaSource {
+	if config.StalenessTimeout <= 0 {
+		config.StalenessTimeout = defaultStalenessTimeout
+	}
+	s := &KafkaSource{
+		consumer: consumer,
+		config:   config,
+		quit:     make(chan struct{}),
+	}
+	go s.loop()
+	return s
+}
+
+func (s *KafkaSource) loop() {
+	for {
+		select {
+		case raw, ok := <-s.consumer.Messages():
+			if !ok {
+				return
+			}
+			s.handle(raw)
+		case <-s.quit:
+			return
+		}
+	}
+}
+
+func (s *KafkaSource) handle(raw []byte) {
+	var msg FeedMessage
+	if err := rlp.DecodeBytes(raw, &msg); err != nil {
+		log.Warn("Discarding malformed gas price feed message", "err", err)
+		return
+	}
+	if err := msg.verify(s.config.PublisherKey); err != nil {
+		log.Warn("Discarding gas price feed message with invalid signature", "err", err)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// A valid signature alone does not prove freshness: a previously-valid
+	// signed message can be replayed verbatim to reset lastSeen and keep a
+	// stale price looking fresh, defeating the StalenessTimeout fallback. A
+	// message is only accepted if it is newer than the last one we did.
+	if msg.Timestamp <= s.last.Timestamp {
+		log.Warn("Discarding stale or replayed gas price feed message", "timestamp", msg.Timestamp, "last", s.last.Timestamp)
+		return
+	}
+
+	s.last = msg
+	s.lastSeen = time.Now()
+}
+
+// Close stops the background consumer loop and closes the underlying
+// KafkaConsumer.
+func (s *KafkaSource) Close() error {
+	close(s.quit)
+	return s.consumer.Close()
+}
+
+// stale reports whether no (verified) message has arrived within the
+// configured staleness timeout.
+func (s *KafkaSource) stale() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.lastSeen.IsZero() || time.Since(s.lastSeen) > s.config.StalenessTimeout
+}
+
+// clamp bounds price between the KafkaSourceConfig's own min/max, so a
+// compromised feed cannot set an absurd price regardless of what it signs.
+func (s *KafkaSource) clamp(price *big.Int) *big.Int {
+	if price == nil {
+		return nil
+	}
+	if s.config.MinGasPrice != nil && price.Cmp(s.config.MinGasPrice) < 0 {
+		return new(big.Int).Set(s.config.MinGasPrice)
+	}
+	if s.config.MaxGasPrice != nil && price.Cmp(s.config.MaxGasPrice) > 0 {
+		return new(big.Int).Set(s.config.MaxGasPrice)
+	}
+	return price
+}
+
+func (s *KafkaSource) SuggestedGasPrice() *big.Int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.clamp(s.last.SuggestedGasPrice)
+}
+
+func (s *KafkaSource) MinGasPrice() *big.Int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.clamp(s.last.MinGasPrice)
+}
+
+func (s *KafkaSource) FixedPriceGasLimit() uint64 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.last.FixedPriceGasLimit
+}
+
+// FallbackSource reads from primary while its feed is fresh, and falls back
+// to a local/default source once primary goes stale (e.g. the coordinator
+// stops publishing).
+type FallbackSource struct {
+	primary  *KafkaSource
+	fallback Source
+}
+
+// NewFallbackSource composes primary and fallback into a single Source.
+func NewFallbackSource(primary *KafkaSource, fallback Source) *FallbackSource {
+	return &FallbackSource{primary: primary, fallback: fallback}
+}
+
+func (s *FallbackSource) active() Source {
+	if s.primary != nil && !s.primary.stale() {
+		return s.primary
+	}
+	return s.fallback
+}
+
+func (s *FallbackSource) SuggestedGasPrice() *big.Int { return s.active().SuggestedGasPrice() }
+func (s *FallbackSource) MinGasPrice() *big.Int       { return s.active().MinGasPrice() }
+func (s *FallbackSource) FixedPriceGasLimit() uint64  { return s.active().FixedPriceGasLimit() }
@@ -0,0 +1,78 @@
+// Copyright 2018 Vixeka Software Solutions, Inc.
+// This file is part of the Theos library.
+//
+// The Theos library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Theos library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Theos library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// FeeComponents splits a transaction's total fee into the portion paid for
+// L2 execution and the portion paid to cover L1 data availability.
+type FeeComponents struct {
+	L2ExecutionFee *big.Int `json:"l2ExecutionFee"`
+	L1DataFee      *big.Int `json:"l1DataFee"`
+}
+
+// PublicFeeComponentsAPI exposes the fee breakdown of a transaction over
+// RPC, so block explorers and wallets can show users why a simple transfer
+// costs more than 21000*gasPrice.
+type PublicFeeComponentsAPI struct {
+	gpm *GasPriceManager
+}
+
+// NewPublicFeeComponentsAPI creates the RPC service backed by gpm.
+func NewPublicFeeComponentsAPI(gpm *GasPriceManager) *PublicFeeComponentsAPI {
+	return &PublicFeeComponentsAPI{gpm: gpm}
+}
+
+// GetFeeComponents returns tx's fee split between L2 execution and L1 data
+// availability, served as theos_getFeeComponents.
+func (api *PublicFeeComponentsAPI) GetFeeComponents(tx *types.Transaction) (*FeeComponents, error) {
+	actualGasPrice, l1DataFee, _ := api.gpm.GetActualGasPrice(tx, uint64(time.Now().Unix()))
+	if l1DataFee == nil {
+		l1DataFee = new(big.Int)
+	}
+
+	return &FeeComponents{
+		L2ExecutionFee: new(big.Int).Mul(actualGasPrice, new(big.Int).SetUint64(tx.Gas())),
+		L1DataFee:      l1DataFee,
+	}, nil
+}
+
+// PrivateAdminGasPriceAPI exposes node-admin operations on the gas price
+// manager, restricted to the admin RPC namespace the same way the rest of
+// the node's admin surface is.
+type PrivateAdminGasPriceAPI struct {
+	gpm *GasPriceManager
+}
+
+// NewPrivateAdminGasPriceAPI creates the RPC service backed by gpm.
+func NewPrivateAdminGasPriceAPI(gpm *GasPriceManager) *PrivateAdminGasPriceAPI {
+	return &PrivateAdminGasPriceAPI{gpm: gpm}
+}
+
+// SetMinGasPrice schedules newMin to become the consensus-level minimum gas
+// price starting at activateAt (a Unix timestamp), served as
+// theos_setMinGasPrice. The change is not enforced until activateAt so the
+// network has time to coordinate on the new floor; activateAt must be in
+// the future or the call fails.
+func (api *PrivateAdminGasPriceAPI) SetMinGasPrice(newMin *big.Int, activateAt uint64) error {
+	return api.gpm.SetMinGasPrice(newMin, activateAt)
+}
@@ -0,0 +1,220 @@
+// Copyright 2018 Vixeka Software Solutions, Inc.
+// This file is part of the Theos library.
+//
+// The Theos library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Theos library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Theos library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"encoding/binary"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	lru "github.com/hashicorp/golang-lru"
+)
+
+// DatabaseIterator is implemented by databases that can iterate their
+// keyspace in order, restricted to keys sharing prefix and starting at start.
+// It mirrors DatabaseReader/DatabaseWriter/DatabaseDeleter in only requiring
+// the narrow slice of the underlying database that this package needs.
+type DatabaseIterator interface {
+	NewIterator(prefix []byte, start []byte) Iterator
+}
+
+// Iterator walks a DatabaseIterator's key range in order and must be
+// released after use.
+type Iterator interface {
+	Next() bool
+	Key() []byte
+	Value() []byte
+	Release()
+}
+
+// fixedPriceCounterPrefix is prepended to every key the fixed price counter
+// writes, so its entries live in their own keyspace within the database.
+var fixedPriceCounterPrefix = []byte("fpc-")
+
+// fixedPriceCounterCacheSize bounds the number of hot (contract, day) entries
+// kept in memory so repeatedly priced contracts do not hit the database.
+const fixedPriceCounterCacheSize = 1024
+
+// fixedPriceCounterDefaultRetentionDays is how many daily buckets are kept
+// around before the pruner drops them.
+const fixedPriceCounterDefaultRetentionDays = 30
+
+// fixedPriceCounterKey returns the database key for the number of
+// fixed-price-applied transactions sent to contract addr on the given UTC day,
+// where day is the Unix day index (seconds since epoch / 86400).
+func fixedPriceCounterKey(addr common.Address, day uint64) []byte {
+	key := make([]byte, len(fixedPriceCounterPrefix)+8+common.AddressLength)
+	copy(key, fixedPriceCounterPrefix)
+	binary.BigEndian.PutUint64(key[len(fixedPriceCounterPrefix):], day)
+	copy(key[len(fixedPriceCounterPrefix)+8:], addr[:])
+	return key
+}
+
+// utcDay converts a unix timestamp into the UTC day index used to bucket the
+// per-contract counters.
+func utcDay(unixTime uint64) uint64 {
+	return unixTime / 86400
+}
+
+// fixedPriceCounterCacheKey is the in-memory LRU key, combining the contract
+// address and the day so hot contracts stay cheap to look up across days.
+type fixedPriceCounterCacheKey struct {
+	addr common.Address
+	day  uint64
+}
+
+// fixedPriceCounter is the persistent, incrementally maintained index backing
+// GasPriceManager.isFixedPriceShouldBeApplied. It replaces the historical
+// full-chain backscan with a single key lookup per (contract, day), updated
+// as blocks are imported or reverted.
+type fixedPriceCounter struct {
+	db    DatabaseReader
+	mu    sync.Mutex
+	cache *lru.Cache
+}
+
+// newFixedPriceCounter creates a counter index backed by db. db must also
+// implement DatabaseWriter and DatabaseDeleter for writes and pruning to
+// succeed; a read-only database only supports Get.
+func newFixedPriceCounter(db DatabaseReader) *fixedPriceCounter {
+	cache, _ := lru.New(fixedPriceCounterCacheSize)
+	return &fixedPriceCounter{
+		db:    db,
+		cache: cache,
+	}
+}
+
+// Get returns the number of fixed-price-applied transactions recorded for
+// addr on day.
+func (c *fixedPriceCounter) Get(addr common.Address, day uint64) uint64 {
+	cacheKey := fixedPriceCounterCacheKey{addr: addr, day: day}
+	if cached, ok := c.cache.Get(cacheKey); ok {
+		return cached.(uint64)
+	}
+
+	data, _ := c.db.Get(fixedPriceCounterKey(addr, day))
+	count := uint64(0)
+	if len(data) == 8 {
+		count = binary.BigEndian.Uint64(data)
+	}
+	c.cache.Add(cacheKey, count)
+	return count
+}
+
+// Add adjusts the counter for addr on day by delta, which may be negative to
+// roll back a reorged block. It is a no-op if the underlying database does
+// not support writes.
+func (c *fixedPriceCounter) Add(addr common.Address, day uint64, delta int64) {
+	writer, ok := c.db.(DatabaseWriter)
+	if !ok {
+		log.Warn("Fixed price counter database does not support writes, skipping update")
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	current := c.Get(addr, day)
+	updated := int64(current) + delta
+	if updated < 0 {
+		updated = 0
+	}
+
+	buf := make([]byte, 8)
+	binary.BigEndian.PutUint64(buf, uint64(updated))
+	if err := writer.Put(fixedPriceCounterKey(addr, day), buf); err != nil {
+		log.Error("Failed to update fixed price counter", "addr", addr, "day", day, "err", err)
+		return
+	}
+	c.cache.Add(fixedPriceCounterCacheKey{addr: addr, day: day}, uint64(updated))
+}
+
+// applyBlock walks the transactions of block and increments the counter for
+// every transaction whose receipt has FixedPriceApplied set, bucketed under
+// the block's own day. It is called by the blockchain writer as part of
+// importing a block. Gating on the receipt flag, rather than re-deriving it
+// from tx.Gas() against the configured limit, means reconfiguring
+// FixedPriceGasLimit mid-chain cannot retroactively change historical quota
+// accounting.
+func (c *fixedPriceCounter) applyBlock(block *types.Block, receipts types.Receipts) {
+	day := utcDay(block.Time().Uint64())
+	for i, tx := range block.Transactions() {
+		if tx.To() == nil || i >= len(receipts) || !receipts[i].FixedPriceApplied {
+			continue
+		}
+		c.Add(*tx.To(), day, 1)
+	}
+}
+
+// revertBlock undoes the effect applyBlock had for block, used when the
+// blockchain writer rolls back a reorged-out block.
+func (c *fixedPriceCounter) revertBlock(block *types.Block, receipts types.Receipts) {
+	day := utcDay(block.Time().Uint64())
+	for i, tx := range block.Transactions() {
+		if tx.To() == nil || i >= len(receipts) || !receipts[i].FixedPriceApplied {
+			continue
+		}
+		c.Add(*tx.To(), day, -1)
+	}
+}
+
+// prune drops every daily bucket older than retentionDays. It is intended to
+// be run periodically from a background goroutine. Rather than depending on a
+// separately maintained index of which (day, addr) pairs were ever written,
+// it iterates the counter's own "fpc-" key range directly.
+func (c *fixedPriceCounter) prune(retentionDays uint64) {
+	deleter, ok := c.db.(DatabaseDeleter)
+	if !ok {
+		return
+	}
+	iterator, ok := c.db.(DatabaseIterator)
+	if !ok {
+		log.Warn("Fixed price counter database does not support iteration, skipping prune")
+		return
+	}
+	cutoff := utcDay(uint64(time.Now().Unix()))
+	if cutoff < retentionDays {
+		return
+	}
+	cutoff -= retentionDays
+
+	it := iterator.NewIterator(fixedPriceCounterPrefix, nil)
+	defer it.Release()
+
+	keyLen := len(fixedPriceCounterPrefix) + 8 + common.AddressLength
+	for it.Next() {
+		key := it.Key()
+		if len(key) != keyLen {
+			continue
+		}
+		day := binary.BigEndian.Uint64(key[len(fixedPriceCounterPrefix):])
+		if day >= cutoff {
+			continue
+		}
+		var addr common.Address
+		copy(addr[:], key[len(fixedPriceCounterPrefix)+8:])
+
+		if err := deleter.Delete(key); err != nil {
+			log.Warn("Failed to prune fixed price counter entry", "addr", addr, "day", day, "err", err)
+			continue
+		}
+		c.cache.Remove(fixedPriceCounterCacheKey{addr: addr, day: day})
+	}
+}
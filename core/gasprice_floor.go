@@ -0,0 +1,146 @@
+// Copyright 2018 Vixeka Software Solutions, Inc.
+// This file is part of the Theos library.
+//
+// The Theos library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Theos library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Theos library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// ErrGasPriceBelowMinimum is returned by ValidateMinGasPrice when a block
+// contains a non-fixed-price transaction priced below the consensus floor in
+// effect at the block's timestamp.
+var ErrGasPriceBelowMinimum = errors.New("transaction gas price below the consensus minimum")
+
+// ErrActivationNotInFuture is returned by SetMinGasPrice when activateAt is
+// not strictly after the current time. Since at() applies the latest
+// schedule entry whose ActivateAt is <= headerTime, a past or present
+// activation would take effect immediately (and retroactively, for recently
+// validated blocks), letting a single admin RPC call diverge this node's
+// consensus validation from the rest of the network.
+var ErrActivationNotInFuture = errors.New("min gas price activation time must be in the future")
+
+// MinGasPriceActivation schedules a new consensus-level minimum gas price
+// that takes effect for every block with a header timestamp at or after
+// ActivateAt, so the network can coordinate a floor change without a hard
+// fork.
+type MinGasPriceActivation struct {
+	ActivateAt uint64   // Unix timestamp at which Min becomes the active floor.
+	Min        *big.Int // Minimum gas price enforced from ActivateAt onward.
+}
+
+// minGasPriceSchedule is the live, mutable set of scheduled floors, guarded
+// separately from GasPriceManagerConfig so theos_setMinGasPrice can append
+// to it at runtime.
+type minGasPriceSchedule struct {
+	mu       sync.RWMutex
+	schedule []MinGasPriceActivation
+}
+
+func newMinGasPriceSchedule(initial []MinGasPriceActivation) *minGasPriceSchedule {
+	s := &minGasPriceSchedule{schedule: append([]MinGasPriceActivation(nil), initial...)}
+	s.sort()
+	return s
+}
+
+func (s *minGasPriceSchedule) sort() {
+	sort.Slice(s.schedule, func(i, j int) bool { return s.schedule[i].ActivateAt < s.schedule[j].ActivateAt })
+}
+
+// add schedules a new floor, keeping the table sorted by activation time.
+func (s *minGasPriceSchedule) add(activation MinGasPriceActivation) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.schedule = append(s.schedule, activation)
+	s.sort()
+}
+
+// at returns the floor in effect at headerTime, or nil if no entry has
+// activated yet.
+func (s *minGasPriceSchedule) at(headerTime uint64) *big.Int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var active *big.Int
+	for _, entry := range s.schedule {
+		if entry.ActivateAt > headerTime {
+			break
+		}
+		active = entry.Min
+	}
+	return active
+}
+
+// GetMinGasPrice returns the consensus-level minimum gas price in effect for
+// a header with the given timestamp, per the schedule in
+// GasPriceManagerConfig.MinGasPriceSchedule (as refreshed by
+// SetMinGasPrice). It returns zero if no floor has activated yet.
+func (gpm *GasPriceManager) GetMinGasPrice(headerTime uint64) *big.Int {
+	if min := gpm.minGasPrice.at(headerTime); min != nil {
+		return new(big.Int).Set(min)
+	}
+	return new(big.Int)
+}
+
+// SetMinGasPrice schedules newMin to become the consensus-level floor
+// starting at activateAt, implementing theos_setMinGasPrice. activateAt must
+// be strictly after the current time: scheduling a future activation,
+// rather than applying it immediately, is what lets the network coordinate
+// on the new floor before it is enforced.
+func (gpm *GasPriceManager) SetMinGasPrice(newMin *big.Int, activateAt uint64) error {
+	if activateAt <= uint64(time.Now().Unix()) {
+		return ErrActivationNotInFuture
+	}
+	gpm.minGasPrice.add(MinGasPriceActivation{ActivateAt: activateAt, Min: newMin})
+	return nil
+}
+
+// ValidateMinGasPrice is hooked into the block validator: it rejects block
+// if any of its transactions is priced below the consensus floor in effect
+// at header.Time, skipping transactions receipts marks as fixed-price
+// applied, since those are explicitly allowed below the floor. It reads the
+// receipt flag rather than re-deriving eligibility from tx.Gas() against the
+// configured limit, so a reconfigured limit cannot retroactively invalidate
+// historical blocks. This is only sound because FixedPriceApplied is itself
+// computed deterministically from the block's own timestamp and day-bucketed
+// counter state (see GasPriceManager.isFixedPriceShouldBeApplied), never
+// from wall-clock time or a live, feed-mutable limit; otherwise two honest
+// nodes replaying the same block at different times could disagree on its
+// validity.
+func (gpm *GasPriceManager) ValidateMinGasPrice(block *types.Block, receipts types.Receipts) error {
+	min := gpm.GetMinGasPrice(block.Time().Uint64())
+	if min.Sign() == 0 {
+		return nil
+	}
+
+	for i, tx := range block.Transactions() {
+		if i < len(receipts) && receipts[i].FixedPriceApplied {
+			// Fixed-price transactions are explicitly allowed below the floor.
+			continue
+		}
+		if tx.GasPrice().Cmp(min) < 0 {
+			return fmt.Errorf("%w: tx %s priced at %s, floor %s", ErrGasPriceBelowMinimum, tx.Hash(), tx.GasPrice(), min)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,138 @@
+// Copyright 2018 Vixeka Software Solutions, Inc.
+// This file is part of the Theos library.
+//
+// The Theos library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Theos library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Theos library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"math/big"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// l1FeeFixedOverhead is added to every transaction's byte-derived gas count
+// before the L1DataFeeConfig.Overhead, to account for the signature and
+// envelope bytes the RLP encoding of the raw transaction does not otherwise
+// price in.
+const l1FeeFixedOverhead = 188
+
+// L1DataFeeConfig holds the parameters needed to price the L1 data
+// availability cost of posting a transaction's batch to a parent chain. It
+// is optional: a nil config (the default) disables the L1 fee component
+// entirely.
+type L1DataFeeConfig struct {
+	L1BaseFee *big.Int // Current L1 base fee, in wei.
+	Overhead  uint64   // Fixed per-batch overhead charged on top of the byte-derived gas.
+	Scalar    uint64   // Scalar applied on top of the L1 gas cost.
+	Decimals  uint8    // Number of decimal places Scalar is expressed in.
+}
+
+// l1FeeState holds the live, refreshable copy of the L1 fee parameters,
+// guarded separately from GasPriceManagerConfig so it can be updated at
+// runtime from a predeploy contract or an admin RPC without touching the
+// rest of the manager's static configuration.
+type l1FeeState struct {
+	mu     sync.RWMutex
+	config *L1DataFeeConfig
+}
+
+func newL1FeeState(config *L1DataFeeConfig) *l1FeeState {
+	state := &l1FeeState{}
+	if config != nil {
+		cfg := *config
+		state.config = &cfg
+	}
+	return state
+}
+
+// Get returns a copy of the current L1 fee config and whether it is enabled.
+func (s *l1FeeState) Get() (L1DataFeeConfig, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.config == nil {
+		return L1DataFeeConfig{}, false
+	}
+	return *s.config, true
+}
+
+// Set refreshes the L1 fee config, e.g. after reading new values from the L1
+// predeploy contract or an admin RPC call.
+func (s *l1FeeState) Set(config L1DataFeeConfig) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	cfg := config
+	s.config = &cfg
+}
+
+// SetL1DataFeeConfig refreshes the parameters used to compute the L1 data
+// fee component. Passing a zero-value config does not disable the feature;
+// construct gpm with a nil L1DataFeeConfig to do that.
+func (gpm *GasPriceManager) SetL1DataFeeConfig(config L1DataFeeConfig) {
+	gpm.l1Fee.Set(config)
+}
+
+// GetL1DataFee returns the L1 data-availability portion of tx's fee, or nil
+// if no L1DataFeeConfig has been configured. It is computed as
+// l1BaseFee * (l1GasUsed + overhead) * scalar / 10^decimals, where l1GasUsed
+// charges 16 gas per non-zero byte and 4 gas per zero byte of the
+// RLP-encoded transaction, plus a fixed per-tx overhead.
+func (gpm *GasPriceManager) GetL1DataFee(tx *types.Transaction) (*big.Int, error) {
+	config, enabled := gpm.l1Fee.Get()
+	if !enabled {
+		return nil, nil
+	}
+
+	raw, err := rlp.EncodeToBytes(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	return computeL1DataFee(l1GasUsedForBytes(raw), config), nil
+}
+
+// computeL1DataFee applies the l1BaseFee * (l1GasUsed + overhead) * scalar /
+// 10^decimals formula described on GetL1DataFee. A nil L1BaseFee (as left by
+// a zero-value L1DataFeeConfig) is treated as zero rather than panicking, per
+// SetL1DataFeeConfig's doc that a zero-value config does not disable the
+// feature.
+func computeL1DataFee(l1GasUsed uint64, config L1DataFeeConfig) *big.Int {
+	l1BaseFee := config.L1BaseFee
+	if l1BaseFee == nil {
+		l1BaseFee = new(big.Int)
+	}
+
+	fee := new(big.Int).Add(new(big.Int).SetUint64(l1GasUsed), new(big.Int).SetUint64(config.Overhead))
+	fee.Mul(fee, l1BaseFee)
+	fee.Mul(fee, new(big.Int).SetUint64(config.Scalar))
+	fee.Div(fee, new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(config.Decimals)), nil))
+
+	return fee
+}
+
+// l1GasUsedForBytes charges 16 gas per non-zero byte and 4 gas per zero byte
+// of data, plus the fixed per-tx overhead.
+func l1GasUsedForBytes(data []byte) uint64 {
+	gas := uint64(l1FeeFixedOverhead)
+	for _, b := range data {
+		if b == 0 {
+			gas += 4
+		} else {
+			gas += 16
+		}
+	}
+	return gas
+}
@@ -0,0 +1,78 @@
+// Copyright 2018 Vixeka Software Solutions, Inc.
+// This file is part of the Theos library.
+//
+// The Theos library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Theos library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Theos library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestComputeL1DataFee(t *testing.T) {
+	tests := []struct {
+		name      string
+		l1GasUsed uint64
+		config    L1DataFeeConfig
+		want      *big.Int
+	}{
+		{
+			name:      "basic formula",
+			l1GasUsed: 1000,
+			config: L1DataFeeConfig{
+				L1BaseFee: big.NewInt(20),
+				Overhead:  100,
+				Scalar:    15,
+				Decimals:  1,
+			},
+			// (1000 + 100) * 20 * 15 / 10 = 33000
+			want: big.NewInt(33000),
+		},
+		{
+			name:      "zero-value config treats nil L1BaseFee as zero",
+			l1GasUsed: 1000,
+			config:    L1DataFeeConfig{},
+			want:      big.NewInt(0),
+		},
+		{
+			name:      "decimals scales down to zero",
+			l1GasUsed: 1,
+			config: L1DataFeeConfig{
+				L1BaseFee: big.NewInt(1),
+				Overhead:  0,
+				Scalar:    1,
+				Decimals:  18,
+			},
+			want: big.NewInt(0),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := computeL1DataFee(tt.l1GasUsed, tt.config); got.Cmp(tt.want) != 0 {
+				t.Errorf("computeL1DataFee() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestL1GasUsedForBytes(t *testing.T) {
+	data := []byte{0x00, 0x01, 0x00, 0xff}
+	// fixed overhead + 2 zero bytes * 4 + 2 non-zero bytes * 16
+	want := uint64(l1FeeFixedOverhead) + 2*4 + 2*16
+	if got := l1GasUsedForBytes(data); got != want {
+		t.Errorf("l1GasUsedForBytes() = %d, want %d", got, want)
+	}
+}
@@ -21,13 +21,23 @@ import (
 	"time"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/gasprice"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/event"
 	"github.com/ethereum/go-ethereum/log"
 )
 
 type GasPriceManager struct {
 	config         GasPriceManagerConfig
 	databaseReader DatabaseReader
+	counter        *fixedPriceCounter
+	oracle         *gasprice.Oracle
+	l1Fee          *l1FeeState
+	minGasPrice    *minGasPriceSchedule
+	source         gasprice.Source
+
+	fixedPriceAppliedFeed  event.Feed
+	fixedPriceAppliedScope event.SubscriptionScope
 }
 
 // GasPriceManagerConfig are the configuration parameters of the gas price manager.
@@ -37,6 +47,20 @@ type GasPriceManagerConfig struct {
 	// gas price of transactions below this gas limit are set to fixed price).
 	FixedPriceTxCountPerContractLimit uint64 // The number of allowed transactions per contract with
 	// fixed price per day.
+	FixedPriceCounterRetentionDays uint64 // The number of daily counter buckets kept before the
+	// background pruner drops them.
+
+	GpoBlocks      int      // Number of recent blocks the gas price oracle samples from.
+	GpoPercentile  int      // Percentile of sampled gas prices the oracle suggests.
+	GpoMinGasPrice *big.Int // Lower bound the oracle's suggestion is clamped to.
+	GpoMaxGasPrice *big.Int // Upper bound the oracle's suggestion is clamped to.
+
+	L1DataFee *L1DataFeeConfig // Optional L1 data-availability fee parameters; nil disables it.
+
+	MinGasPriceSchedule []MinGasPriceActivation // Consensus-level gas price floors, by activation timestamp.
+
+	GasPriceSource gasprice.Source // Optional follower-mode source (e.g. gasprice.NewFallbackSource);
+	// nil builds the default LocalSource wrapping this manager's own oracle and config.
 }
 
 // DefaultGasPriceManagerConfig contains the default configurations for the transaction
@@ -45,12 +69,28 @@ var DefaultGasPriceManagerConfig = GasPriceManagerConfig{
 	FixedPrice:                        0,
 	FixedPriceGasLimit:                100000,
 	FixedPriceTxCountPerContractLimit: 10000,
+	FixedPriceCounterRetentionDays:    fixedPriceCounterDefaultRetentionDays,
+	GpoBlocks:                         gasprice.DefaultConfig.Blocks,
+	GpoPercentile:                     gasprice.DefaultConfig.Percentile,
+	GpoMinGasPrice:                    gasprice.DefaultConfig.MinGasPrice,
+	GpoMaxGasPrice:                    gasprice.DefaultConfig.MaxGasPrice,
 }
 
-// Calculates the expected fixed price based on the number of transactions in the database.
+// SetExpectedGasPrice sets tx's expected gas price to a sensible estimate for
+// wallets: the configured fixed price if tx is eligible for it, or otherwise
+// the dynamic oracle's live suggestion. This is an estimate only - unlike
+// GetActualGasPrice, which charges tx's own gasPrice for execution, this
+// substitutes the oracle's suggestion so the estimate tracks current network
+// conditions.
 func SetExpectedGasPrice(databaseReader DatabaseReader, tx *types.Transaction) {
 	gasPriceManager := NewGasPriceManager(databaseReader)
-	actualGasPrice, _ := gasPriceManager.GetActualGasPrice(tx.To(), tx.Gas(), tx.GasPrice())
+	actualGasPrice, _, isFixedPriceApplied := gasPriceManager.GetActualGasPrice(tx, uint64(time.Now().Unix()))
+	if !isFixedPriceApplied {
+		// The manager was just created, so its oracle's cache has not been
+		// refreshed by ApplyBlock yet; sample the chain once before reading it.
+		gasPriceManager.oracle.Update()
+		actualGasPrice = gasPriceManager.source.SuggestedGasPrice()
+	}
 	tx.SetExpectedGasPrice(actualGasPrice)
 }
 
@@ -62,88 +102,203 @@ func NewGasPriceManager(databaseReader DatabaseReader) *GasPriceManager {
 	gpm := &GasPriceManager{
 		config:         config,
 		databaseReader: databaseReader,
+		counter:        newFixedPriceCounter(databaseReader),
+	}
+	gpm.oracle = gasprice.NewOracle(&gasPriceOracleBackend{databaseReader: databaseReader}, gasprice.Config{
+		Blocks:      config.GpoBlocks,
+		Percentile:  config.GpoPercentile,
+		MinGasPrice: config.GpoMinGasPrice,
+		MaxGasPrice: config.GpoMaxGasPrice,
+	})
+	gpm.l1Fee = newL1FeeState(config.L1DataFee)
+	gpm.minGasPrice = newMinGasPriceSchedule(config.MinGasPriceSchedule)
+
+	if config.GasPriceSource != nil {
+		gpm.source = config.GasPriceSource
+	} else {
+		gpm.source = &gasprice.LocalSource{
+			SuggestedGasPriceFn:  gpm.oracle.SuggestedPrice,
+			MinGasPriceFn:        func() *big.Int { return gpm.GetMinGasPrice(uint64(time.Now().Unix())) },
+			FixedPriceGasLimitFn: func() uint64 { return gpm.config.FixedPriceGasLimit },
+		}
 	}
 
 	return gpm
 }
 
-func (gpm *GasPriceManager) GetActualGasPrice(to *common.Address, gasUsed uint64, gasPrice *big.Int) (actualGasPrice *big.Int, isFixedPriceApplied bool) {
+// Oracle returns the dynamic gas price oracle consulted whenever the fixed
+// price does not apply, so it can be exposed over RPC (e.g. eth_gasPrice) or
+// subscribed to by the txpool.
+func (gpm *GasPriceManager) Oracle() *gasprice.Oracle {
+	return gpm.oracle
+}
+
+// Stop closes every subscription registered with the manager's oracle and
+// fixed-price-applied event feed.
+func (gpm *GasPriceManager) Stop() {
+	gpm.oracle.Stop()
+	gpm.fixedPriceAppliedScope.Close()
+}
+
+// CurrentMinGasPrice returns the minimum gas price as reported by the active
+// gas price source (LocalSource by default, or a follower-mode feed), for
+// informational use by the RPC and txpool. It intentionally does not back
+// consensus validation: GetMinGasPrice is driven solely by this node's own
+// MinGasPriceSchedule, so historical blocks replay deterministically
+// regardless of which source is configured live.
+func (gpm *GasPriceManager) CurrentMinGasPrice() *big.Int {
+	return gpm.source.MinGasPrice()
+}
 
-	actualGasPrice = gasPrice
-	isFixedPriceApplied = false
-	if gpm.isFixedPriceShouldBeApplied(to, gasUsed) {
-		actualGasPrice = big.NewInt(int64(gpm.config.FixedPrice))
-		isFixedPriceApplied = true
+// ApplyBlock is called by the blockchain writer when block is imported as
+// part of the canonical chain, with the receipts produced by processing it.
+// It feeds the per-contract daily counter so isFixedPriceShouldBeApplied can
+// answer with a single key lookup instead of re-scanning the chain, gating
+// strictly on each receipt's FixedPriceApplied flag rather than re-deriving
+// it from tx.Gas() against a limit that may since have been reconfigured. It
+// also refreshes the dynamic oracle's cached suggestion once per block, so
+// reads of that suggestion (e.g. eth_gasPrice) do not each re-sample the
+// chain.
+func (gpm *GasPriceManager) ApplyBlock(block *types.Block, receipts types.Receipts) {
+	gpm.counter.applyBlock(block, receipts)
+	gpm.oracle.Update()
+
+	for i, tx := range block.Transactions() {
+		if tx.To() == nil || i >= len(receipts) || !receipts[i].FixedPriceApplied {
+			continue
+		}
+		gpm.fixedPriceAppliedFeed.Send(FixedPriceAppliedEvent{
+			TxHash:      tx.Hash(),
+			To:          *tx.To(),
+			BlockNumber: block.Number(),
+		})
 	}
+}
 
-	return actualGasPrice, isFixedPriceApplied
+// RevertBlock undoes the effect ApplyBlock had for block, and must be called
+// by the blockchain writer for every block that is removed from the
+// canonical chain during a reorg.
+func (gpm *GasPriceManager) RevertBlock(block *types.Block, receipts types.Receipts) {
+	gpm.counter.revertBlock(block, receipts)
 }
 
-func (gpm *GasPriceManager) isFixedPriceShouldBeApplied(to *common.Address, txGasUsed uint64) bool {
+// fixedPriceCounterMigratedKey records that MigrateFixedPriceCounter has
+// already run once against this database, so a second boot does not
+// replay the same 24h of blocks and double-count every bucket.
+var fixedPriceCounterMigratedKey = []byte("fpc-migrated")
 
-	// If the transaction gas is over the fixed price limit, do not continue. As the fixed price
-	// cannot be applied.
-	if txGasUsed > gpm.config.FixedPriceGasLimit {
-		return false
+// MigrateFixedPriceCounter populates the counter index from scratch by
+// replaying the last 24h of blocks starting at head. It is meant to be run
+// once on first boot after upgrading to the indexed counter, so historical
+// quotas are not lost. It is idempotent, guarded by a persisted marker, and
+// increments the counter directly rather than going through ApplyBlock, so
+// replaying history does not also resample the dynamic oracle or fire live
+// fixedPriceAppliedFeed events for 24h of old blocks.
+func (gpm *GasPriceManager) MigrateFixedPriceCounter() {
+	if migrated, _ := gpm.databaseReader.Get(fixedPriceCounterMigratedKey); len(migrated) != 0 {
+		return
 	}
 
 	blockHash := GetHeadBlockHash(gpm.databaseReader)
 	if blockHash == (common.Hash{}) {
-		// Corrupt or empty database, init from scratch
-		log.Warn("Empty database, gas considered as below the limit to apply the fixed price")
-		return true
+		return
 	}
-
 	blockNumber := GetBlockNumber(gpm.databaseReader, blockHash)
 	if blockNumber == missingNumber {
-		// Corrupt or empty database
-		log.Warn("Empty database, gas considered as below the limit to apply the fixed price")
-		return true
+		return
 	}
 
-	fixedPriceTxCountPerContract := uint64(0)
-
-	yearOfNow, monthOfNow, dayOfNow := time.Now().UTC().Date()
-	dayBeginningOfNowTime := big.NewInt(time.Date(yearOfNow, monthOfNow, dayOfNow, 0, 0, 0, 0, time.UTC).Unix())
-
-	for {
-		if blockHash == (common.Hash{}) {
-			break
-		}
-
+	cutoff := big.NewInt(time.Now().Add(-24 * time.Hour).Unix())
+	for blockHash != (common.Hash{}) {
 		block := GetBlock(gpm.databaseReader, blockHash, blockNumber)
-
 		if block == nil {
-			// Corrupt database
-			log.Warn("Could not return block data, gas considered as over the limit to apply the fixed price")
-			return false
+			log.Warn("Could not return block data while migrating fixed price counter")
+			return
 		}
-
-		if block.Time().CmpAbs(dayBeginningOfNowTime) < 0 {
-			// Leaves the loop as this means we already processed all required blocks.
+		if block.Time().CmpAbs(cutoff) < 0 {
 			break
 		}
 
-		if block.Transactions() != nil {
-			for _, transaction := range block.Transactions() {
-				// Now we detect whether the Fixed Price was applied to transaction just checking that
-				// the trasaction used gas is below the GasLimit.
-				// TODO: In future implement the solution that should mark the transaction where
-				// FixedPrice was applied.
-				if transaction.Gas() <= gpm.config.FixedPriceGasLimit && *transaction.To() == *to {
-					fixedPriceTxCountPerContract++
-				}
-			}
-		}
-
-		if fixedPriceTxCountPerContract > gpm.config.FixedPriceTxCountPerContractLimit {
-			break
-		}
+		gpm.counter.applyBlock(block, GetBlockReceipts(gpm.databaseReader, blockHash, blockNumber))
 
 		blockHash = block.ParentHash()
-		blockNumber = blockNumber - 1
+		blockNumber--
+	}
+
+	writer, ok := gpm.databaseReader.(DatabaseWriter)
+	if !ok {
+		log.Warn("Fixed price counter database does not support writes, skipping migration marker")
+		return
+	}
+	if err := writer.Put(fixedPriceCounterMigratedKey, []byte{1}); err != nil {
+		log.Error("Failed to persist fixed price counter migration marker", "err", err)
+	}
+}
+
+// PruneFixedPriceCounter drops daily counter buckets older than the
+// configured retention window. It is intended to be invoked periodically by
+// a background goroutine started alongside the blockchain writer.
+func (gpm *GasPriceManager) PruneFixedPriceCounter() {
+	gpm.counter.prune(gpm.config.FixedPriceCounterRetentionDays)
+}
+
+// GetActualGasPrice returns the gas price that should be charged for tx's
+// L2 execution (the configured fixed price if tx qualifies, otherwise tx's
+// own gasPrice - never the dynamic oracle's suggestion, which is node-local
+// and time-varying and so cannot back a consensus-charged amount), the L1
+// data-availability fee (nil if no L1DataFeeConfig is configured), and
+// whether the fixed price was applied, as of blockTime (the Unix timestamp
+// of the block tx is being processed in, or the current time when called
+// outside of block processing, e.g. for a pre-execution estimate).
+func (gpm *GasPriceManager) GetActualGasPrice(tx *types.Transaction, blockTime uint64) (actualGasPrice *big.Int, l1DataFee *big.Int, isFixedPriceApplied bool) {
+
+	if gpm.isFixedPriceShouldBeApplied(tx.To(), tx.Gas(), blockTime) {
+		actualGasPrice, isFixedPriceApplied = big.NewInt(int64(gpm.config.FixedPrice)), true
+	} else {
+		// Not eligible for the fixed price: charge exactly what the sender
+		// offered. The dynamic oracle's suggestion must never back the price
+		// actually charged for execution, since it is node-local and
+		// time-varying; it is only ever substituted as an estimate, in
+		// SetExpectedGasPrice.
+		actualGasPrice = tx.GasPrice()
 	}
 
-	// If the number of transactions below the limit.
+	l1DataFee, err := gpm.GetL1DataFee(tx)
+	if err != nil {
+		log.Warn("Failed to compute L1 data fee", "err", err)
+		l1DataFee = nil
+	}
+
+	return actualGasPrice, l1DataFee, isFixedPriceApplied
+}
+
+// isFixedPriceShouldBeApplied decides fixed-price eligibility as of
+// blockTime (the Unix timestamp of the block the transaction is being
+// processed in). This decision feeds the consensus FixedPriceApplied receipt
+// field, so it must be reproducible when a node replays a historical block:
+// it is gated on the manager's static FixedPriceGasLimit rather than the
+// live, feed-mutable gas price source, and the per-contract count is read
+// from blockTime's own day bucket rather than the wall-clock day, so
+// re-processing an old block reads the counter state as of that block
+// instead of today's (near-empty) bucket.
+func (gpm *GasPriceManager) isFixedPriceShouldBeApplied(to *common.Address, txGasUsed uint64, blockTime uint64) bool {
+
+	// If the transaction gas is over the fixed price limit, do not continue.
+	// As the fixed price cannot be applied.
+	if txGasUsed > gpm.config.FixedPriceGasLimit {
+		return false
+	}
+
+	if to == nil {
+		return false
+	}
+
+	// The per-contract count used to live by walking the chain backward from head for
+	// every priced transaction; it is now a single key lookup against the counter index
+	// maintained by ApplyBlock/RevertBlock as blocks are imported and reverted. Each
+	// counter change is its own database write, not part of a batch with the block.
+	day := utcDay(blockTime)
+	fixedPriceTxCountPerContract := gpm.counter.Get(*to, day)
+
 	return fixedPriceTxCountPerContract <= gpm.config.FixedPriceTxCountPerContractLimit
 }
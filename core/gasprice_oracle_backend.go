@@ -0,0 +1,54 @@
+// Copyright 2018 Vixeka Software Solutions, Inc.
+// This file is part of the Theos library.
+//
+// The Theos library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Theos library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Theos library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// gasPriceOracleBackend adapts the database accessors already used by
+// GasPriceManager into the narrow gasprice.Backend interface, so
+// core/gasprice does not need to depend on core and create an import cycle.
+type gasPriceOracleBackend struct {
+	databaseReader DatabaseReader
+}
+
+// RecentBlocks implements gasprice.Backend.
+func (b *gasPriceOracleBackend) RecentBlocks(n int) []*types.Block {
+	blocks := make([]*types.Block, 0, n)
+
+	blockHash := GetHeadBlockHash(b.databaseReader)
+	if blockHash == (common.Hash{}) {
+		return blocks
+	}
+	blockNumber := GetBlockNumber(b.databaseReader, blockHash)
+	if blockNumber == missingNumber {
+		return blocks
+	}
+
+	for i := 0; i < n && blockHash != (common.Hash{}); i++ {
+		block := GetBlock(b.databaseReader, blockHash, blockNumber)
+		if block == nil {
+			break
+		}
+		blocks = append(blocks, block)
+		blockHash = block.ParentHash()
+		blockNumber--
+	}
+	return blocks
+}
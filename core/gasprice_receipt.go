@@ -0,0 +1,48 @@
+// Copyright 2018 Vixeka Software Solutions, Inc.
+// This file is part of the Theos library.
+//
+// The Theos library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Theos library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Theos library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/event"
+)
+
+// FixedPriceAppliedEvent is emitted for every transaction receipt with
+// FixedPriceApplied set, so indexers can track fixed-price usage without
+// re-deriving it from tx.Gas() against the (possibly since reconfigured)
+// FixedPriceGasLimit.
+type FixedPriceAppliedEvent struct {
+	TxHash      common.Hash
+	To          common.Address
+	BlockNumber *big.Int
+}
+
+// ApplyFixedPriceToReceipt is called by the state processor right after
+// executing tx, recording on receipt the same isFixedPriceApplied decision
+// GetActualGasPrice made when the transaction was priced.
+func ApplyFixedPriceToReceipt(receipt *types.Receipt, isFixedPriceApplied bool) {
+	receipt.FixedPriceApplied = isFixedPriceApplied
+}
+
+// SubscribeFixedPriceApplied registers ch to receive a FixedPriceAppliedEvent
+// for every receipt ApplyBlock processes with FixedPriceApplied set.
+func (gpm *GasPriceManager) SubscribeFixedPriceApplied(ch chan<- FixedPriceAppliedEvent) event.Subscription {
+	return gpm.fixedPriceAppliedScope.Track(gpm.fixedPriceAppliedFeed.Subscribe(ch))
+}
@@ -0,0 +1,135 @@
+// Copyright 2018 Vixeka Software Solutions, Inc.
+// This file is part of the Theos library.
+//
+// The Theos library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Theos library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Theos library. If not, see <http://www.gnu.org/licenses/>.
+
+package types
+
+import (
+	"io"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// Receipt status codes.
+const (
+	ReceiptStatusFailed     = uint64(0)
+	ReceiptStatusSuccessful = uint64(1)
+)
+
+// Receipt represents the results of a transaction.
+type Receipt struct {
+	// Consensus fields
+	PostState         []byte `json:"root"`
+	Status            uint64 `json:"status"`
+	CumulativeGasUsed uint64 `json:"cumulativeGasUsed"`
+	Bloom             Bloom  `json:"logsBloom"`
+	Logs              []*Log `json:"logs"`
+
+	// FixedPriceApplied records whether GasPriceManager.GetActualGasPrice
+	// applied the fixed price to the transaction this receipt is for. It is
+	// set once, by the state processor, from the same decision that priced
+	// the transaction, rather than being re-derived later from tx.Gas()
+	// against a FixedPriceGasLimit that may since have been reconfigured.
+	//
+	// It is declared optional in the RLP encoding (see receiptRLP) so
+	// receipts persisted before this field existed keep decoding, as false.
+	FixedPriceApplied bool `json:"fixedPriceApplied"`
+
+	// Implementation fields (not part of consensus)
+	TxHash          common.Hash    `json:"transactionHash"`
+	ContractAddress common.Address `json:"contractAddress"`
+	GasUsed         uint64         `json:"gasUsed"`
+
+	// Inclusion information
+	BlockHash        common.Hash `json:"blockHash,omitempty"`
+	BlockNumber      *big.Int    `json:"blockNumber,omitempty"`
+	TransactionIndex uint        `json:"transactionIndex"`
+}
+
+// receiptRLP is the consensus encoding of a Receipt. FixedPriceApplied is
+// tagged "optional" so a receipt encoded before it existed still decodes
+// (with FixedPriceApplied defaulting to false), and the encoder may omit it
+// when false so nodes on the previous receipt version can still read it.
+type receiptRLP struct {
+	PostStateOrStatus []byte
+	CumulativeGasUsed uint64
+	Bloom             Bloom
+	Logs              []*Log
+	FixedPriceApplied bool `rlp:"optional"`
+}
+
+// NewReceipt creates a barebones transaction receipt, copying the init
+// fields. The fixed-price flag must be set separately.
+func NewReceipt(root []byte, failed bool, cumulativeGasUsed uint64) *Receipt {
+	r := &Receipt{PostState: common.CopyBytes(root), CumulativeGasUsed: cumulativeGasUsed}
+	if failed {
+		r.Status = ReceiptStatusFailed
+	} else {
+		r.Status = ReceiptStatusSuccessful
+	}
+	return r
+}
+
+// statusEncoding returns the consensus encoding of the receipt's status
+// field, mirroring the historical PostState-or-Status byzantium switch.
+func (r *Receipt) statusEncoding() []byte {
+	if len(r.PostState) == 0 {
+		if r.Status == ReceiptStatusFailed {
+			return []byte{}
+		}
+		return []byte{1}
+	}
+	return r.PostState
+}
+
+// setStatus decodes the consensus status encoding back into PostState/Status.
+func (r *Receipt) setStatus(postStateOrStatus []byte) error {
+	switch len(postStateOrStatus) {
+	case 0:
+		r.Status = ReceiptStatusFailed
+	case 1:
+		r.Status = ReceiptStatusSuccessful
+	default:
+		r.PostState = postStateOrStatus
+	}
+	return nil
+}
+
+// EncodeRLP implements rlp.Encoder.
+func (r *Receipt) EncodeRLP(w io.Writer) error {
+	return rlp.Encode(w, &receiptRLP{r.statusEncoding(), r.CumulativeGasUsed, r.Bloom, r.Logs, r.FixedPriceApplied})
+}
+
+// DecodeRLP implements rlp.Decoder.
+func (r *Receipt) DecodeRLP(s *rlp.Stream) error {
+	var dec receiptRLP
+	if err := s.Decode(&dec); err != nil {
+		return err
+	}
+	if err := r.setStatus(dec.PostStateOrStatus); err != nil {
+		return err
+	}
+	r.CumulativeGasUsed, r.Bloom, r.Logs = dec.CumulativeGasUsed, dec.Bloom, dec.Logs
+	r.FixedPriceApplied = dec.FixedPriceApplied
+	return nil
+}
+
+// Receipts is a wrapper around a Receipt slice.
+type Receipts []*Receipt
+
+// Len returns the number of receipts in this list.
+func (r Receipts) Len() int { return len(r) }
@@ -0,0 +1,81 @@
+// Copyright 2018 Vixeka Software Solutions, Inc.
+// This file is part of the Theos library.
+//
+// The Theos library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Theos library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Theos library. If not, see <http://www.gnu.org/licenses/>.
+
+package types
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+func TestReceiptRLPRoundTrip(t *testing.T) {
+	for _, applied := range []bool{false, true} {
+		r := &Receipt{
+			Status:            ReceiptStatusSuccessful,
+			CumulativeGasUsed: 21000,
+			FixedPriceApplied: applied,
+		}
+
+		enc, err := rlp.EncodeToBytes(r)
+		if err != nil {
+			t.Fatalf("EncodeToBytes(FixedPriceApplied=%v) failed: %v", applied, err)
+		}
+
+		var dec Receipt
+		if err := rlp.DecodeBytes(enc, &dec); err != nil {
+			t.Fatalf("DecodeBytes(FixedPriceApplied=%v) failed: %v", applied, err)
+		}
+		if dec.CumulativeGasUsed != r.CumulativeGasUsed {
+			t.Errorf("CumulativeGasUsed = %d, want %d", dec.CumulativeGasUsed, r.CumulativeGasUsed)
+		}
+		if dec.FixedPriceApplied != applied {
+			t.Errorf("FixedPriceApplied = %v, want %v", dec.FixedPriceApplied, applied)
+		}
+	}
+}
+
+// TestReceiptRLPBackwardCompat verifies that a receipt encoded before
+// FixedPriceApplied existed (a 4-field receiptRLP list) still decodes, with
+// FixedPriceApplied defaulting to false, since the field is tagged optional.
+func TestReceiptRLPBackwardCompat(t *testing.T) {
+	type legacyReceiptRLP struct {
+		PostStateOrStatus []byte
+		CumulativeGasUsed uint64
+		Bloom             Bloom
+		Logs              []*Log
+	}
+
+	legacy := legacyReceiptRLP{
+		PostStateOrStatus: []byte{1},
+		CumulativeGasUsed: 21000,
+	}
+	enc, err := rlp.EncodeToBytes(&legacy)
+	if err != nil {
+		t.Fatalf("EncodeToBytes(legacy) failed: %v", err)
+	}
+
+	var dec Receipt
+	if err := rlp.DecodeBytes(enc, &dec); err != nil {
+		t.Fatalf("DecodeBytes(legacy) failed: %v", err)
+	}
+	if dec.CumulativeGasUsed != legacy.CumulativeGasUsed {
+		t.Errorf("CumulativeGasUsed = %d, want %d", dec.CumulativeGasUsed, legacy.CumulativeGasUsed)
+	}
+	if dec.FixedPriceApplied {
+		t.Errorf("FixedPriceApplied = true, want false for a pre-upgrade receipt")
+	}
+}